@@ -7,6 +7,8 @@ package btcutil
 import (
 	"bytes"
 	"fmt"
+
+	"github.com/MassGrid/btcutil/chainhash"
 	"github.com/conformal/btcwire"
 )
 
@@ -29,12 +31,15 @@ func (e OutOfRangeError) Error() string {
 // transactions on their first access so subsequent accesses don't have to
 // repeat the relatively expensive hashing operations.
 type Block struct {
-	msgBlock        *btcwire.MsgBlock  // Underlying MsgBlock
-	serializedBlock []byte             // Serialized bytes for the block
-	blockSha        *btcwire.ShaHash   // Cached block hash
-	blockHeight     int64              // Height in the main block chain
-	txShas          []*btcwire.ShaHash // Cached transaction hashes
-	txShasGenerated bool               // ALL transaction hashes generated
+	msgBlock          *btcwire.MsgBlock   // Underlying MsgBlock
+	serializedBlock   []byte              // Serialized bytes for the block
+	blockHash         *chainhash.Hash     // Cached block hash
+	blockHeight       int64               // Height in the main block chain
+	txHashes          []*chainhash.Hash   // Cached transaction hashes
+	txHashesGenerated bool                // ALL transaction hashes generated
+	transactions      []*Tx               // Cached wrapped transactions
+	txGenerated       bool                // ALL wrapped transactions generated
+	merkleTree        [][]*chainhash.Hash // Cached merkle tree, leaves first
 }
 
 // MsgBlock returns the underlying btcwire.MsgBlock for the Block.
@@ -65,30 +70,48 @@ func (b *Block) Bytes() ([]byte, error) {
 	return serializedBlock, nil
 }
 
-// Sha returns the block identifier hash for the Block.  This is equivalent to
-// calling BlockSha on the underlying btcwire.MsgBlock, however it caches the
-// result so subsequent calls are more efficient.
-func (b *Block) Sha() (*btcwire.ShaHash, error) {
+// Hash returns the block identifier hash for the Block.  This is equivalent
+// to calling BlockSha on the underlying btcwire.MsgBlock, however it caches
+// the result so subsequent calls are more efficient.
+func (b *Block) Hash() (*chainhash.Hash, error) {
 	// Return the cached block hash if it has already been generated.
-	if b.blockSha != nil {
-		return b.blockSha, nil
+	if b.blockHash != nil {
+		return b.blockHash, nil
 	}
 
 	// Generate the block hash.  Ignore the error since BlockSha can't
 	// currently fail.
 	sha, _ := b.msgBlock.BlockSha()
+	hash, err := chainhash.NewHash(sha[:])
+	if err != nil {
+		return nil, err
+	}
 
 	// Cache the block hash and return it.
-	b.blockSha = &sha
+	b.blockHash = hash
+	return hash, nil
+}
+
+// Sha returns the block identifier hash for the Block.
+//
+// Deprecated: Use Hash instead.
+func (b *Block) Sha() (*btcwire.ShaHash, error) {
+	hash, err := b.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	var sha btcwire.ShaHash
+	copy(sha[:], hash[:])
 	return &sha, nil
 }
 
-// TxSha returns the hash for the requested transaction number in the Block.
-// The supplied index is 0 based.  That is to say, the first transaction is the
-// block is txNum 0.  This is equivalent to calling TxSha on the underlying
-// btcwire.MsgTx, however it caches the result so subsequent calls are more
-// efficient.
-func (b *Block) TxSha(txNum int) (*btcwire.ShaHash, error) {
+// TxHash returns the hash for the requested transaction number in the Block.
+// The supplied index is 0 based.  That is to say, the first transaction in
+// the block is txNum 0.  This is equivalent to calling TxSha on the
+// underlying btcwire.MsgTx, however it caches the result so subsequent calls
+// are more efficient.
+func (b *Block) TxHash(txNum int) (*chainhash.Hash, error) {
 	// Ensure the requested transaction is in range.
 	numTx := b.msgBlock.Header.TxnCount
 	if txNum < 0 || uint64(txNum) > numTx {
@@ -98,57 +121,163 @@ func (b *Block) TxSha(txNum int) (*btcwire.ShaHash, error) {
 	}
 
 	// Generate slice to hold all of the transaction hashes if needed.
-	if len(b.txShas) == 0 {
-		b.txShas = make([]*btcwire.ShaHash, numTx)
+	if len(b.txHashes) == 0 {
+		b.txHashes = make([]*chainhash.Hash, numTx)
 	}
 
 	// Return the cached hash if it has already been generated.
-	if b.txShas[txNum] != nil {
-		return b.txShas[txNum], nil
+	if b.txHashes[txNum] != nil {
+		return b.txHashes[txNum], nil
 	}
 
 	// Generate the hash for the transaction.  Ignore the error since TxSha
 	// can't currently fail.
 	sha, _ := b.msgBlock.Transactions[txNum].TxSha()
+	hash, err := chainhash.NewHash(sha[:])
+	if err != nil {
+		return nil, err
+	}
 
 	// Cache the transaction hash and return it.
-	b.txShas[txNum] = &sha
+	b.txHashes[txNum] = hash
+	return hash, nil
+}
+
+// TxSha returns the hash for the requested transaction number in the Block.
+//
+// Deprecated: Use TxHash instead.
+func (b *Block) TxSha(txNum int) (*btcwire.ShaHash, error) {
+	hash, err := b.TxHash(txNum)
+	if err != nil {
+		return nil, err
+	}
+
+	var sha btcwire.ShaHash
+	copy(sha[:], hash[:])
 	return &sha, nil
 }
 
-// TxShas returns a slice of hashes for all transactions in the Block.  This is
-// equivalent to calling TxSha on each underlying btcwire.MsgTx, however it
+// TxHashes returns a slice of hashes for all transactions in the Block.  This
+// is equivalent to calling TxSha on each underlying btcwire.MsgTx, however it
 // caches the result so subsequent calls are more efficient.
-func (b *Block) TxShas() ([]*btcwire.ShaHash, error) {
+func (b *Block) TxHashes() ([]*chainhash.Hash, error) {
 	// Return cached hashes if they have ALL already been generated.  This
 	// flag is necessary because the transaction hashes are lazily generated
 	// in a sparse fashion.
-	if b.txShasGenerated {
-		return b.txShas, nil
+	if b.txHashesGenerated {
+		return b.txHashes, nil
 	}
 
 	// Generate slice to hold all of the transaction hashes if needed.
-	if len(b.txShas) == 0 {
-		b.txShas = make([]*btcwire.ShaHash, b.msgBlock.Header.TxnCount)
+	if len(b.txHashes) == 0 {
+		b.txHashes = make([]*chainhash.Hash, b.msgBlock.Header.TxnCount)
 	}
 
 	// Generate and cache the transaction hashes for all that haven't already
 	// been done.
-	for i, hash := range b.txShas {
+	for i, hash := range b.txHashes {
 		if hash == nil {
 			// Ignore the error since TxSha can't currently fail.
 			sha, _ := b.msgBlock.Transactions[i].TxSha()
-			b.txShas[i] = &sha
+			newHash, err := chainhash.NewHash(sha[:])
+			if err != nil {
+				return nil, err
+			}
+			b.txHashes[i] = newHash
+		}
+	}
+
+	b.txHashesGenerated = true
+	return b.txHashes, nil
+}
+
+// TxShas returns a slice of hashes for all transactions in the Block.
+//
+// Deprecated: Use TxHashes instead.
+func (b *Block) TxShas() ([]*btcwire.ShaHash, error) {
+	hashes, err := b.TxHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	shas := make([]*btcwire.ShaHash, len(hashes))
+	for i, hash := range hashes {
+		var sha btcwire.ShaHash
+		copy(sha[:], hash[:])
+		shas[i] = &sha
+	}
+	return shas, nil
+}
+
+// Transactions returns a slice of wrapped transactions (btcutil.Tx) for all
+// transactions in the Block.  This is nearly equivalent to accessing the
+// raw transactions (msgBlock.Transactions), however it caches the wrapped
+// versions so subsequent calls are more efficient.
+func (b *Block) Transactions() []*Tx {
+	// Return transactions if they have ALL already been generated.  This
+	// flag is necessary because the wrapped transactions are lazily
+	// generated in a sparse fashion.
+	if b.txGenerated {
+		return b.transactions
+	}
+
+	// Generate slice to hold all of the wrapped transactions if needed.
+	if len(b.transactions) == 0 {
+		b.transactions = make([]*Tx, len(b.msgBlock.Transactions))
+	}
+
+	// Generate and cache the wrapped transactions for all that haven't
+	// already been done.
+	for i, tx := range b.transactions {
+		if tx == nil {
+			newTx := NewTx(b.msgBlock.Transactions[i])
+			newTx.SetIndex(i)
+			b.transactions[i] = newTx
 		}
 	}
 
-	b.txShasGenerated = true
-	return b.txShas, nil
+	b.txGenerated = true
+	return b.transactions
+}
+
+// Tx returns a wrapped transaction (btcutil.Tx) for the transaction at the
+// specified index in the Block.  The supplied index is 0 based.  That is to
+// say, the first transaction in the block is txNum 0.  This is nearly
+// equivalent to accessing the raw transaction (msgBlock.Transactions),
+// however it caches the wrapped version so subsequent accesses are more
+// efficient.
+func (b *Block) Tx(txNum int) (*Tx, error) {
+	// Ensure the requested transaction is in range.
+	numTx := len(b.msgBlock.Transactions)
+	if txNum < 0 || txNum > numTx-1 {
+		str := fmt.Sprintf("transaction index %d is out of range - max %d",
+			txNum, numTx-1)
+		return nil, OutOfRangeError(str)
+	}
+
+	// Generate slice to hold all of the wrapped transactions if needed.
+	if len(b.transactions) == 0 {
+		b.transactions = make([]*Tx, numTx)
+	}
+
+	// Return the wrapped transaction if it has already been generated.
+	if b.transactions[txNum] != nil {
+		return b.transactions[txNum], nil
+	}
+
+	// Generate and cache the wrapped transaction and return it.
+	newTx := NewTx(b.msgBlock.Transactions[txNum])
+	newTx.SetIndex(txNum)
+	b.transactions[txNum] = newTx
+	return newTx, nil
 }
 
 // TxLoc returns the offsets and lengths of each transaction in a raw block.
 // It is used to allow fast indexing into transactions within the raw byte
-// stream.
+// stream.  When the block's serialized bytes are already cached, the
+// wrapped transactions returned by Transactions and Tx are populated with
+// their serialized bytes by slicing directly into the cached buffer instead
+// of being re-serialized individually.
 func (b *Block) TxLoc() ([]btcwire.TxLoc, error) {
 	rawMsg, err := b.Bytes()
 	if err != nil {
@@ -161,6 +290,22 @@ func (b *Block) TxLoc() ([]btcwire.TxLoc, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	if len(b.serializedBlock) != 0 {
+		if len(b.transactions) == 0 {
+			b.transactions = make([]*Tx, len(txLocs))
+		}
+		for i, loc := range txLocs {
+			if b.transactions[i] == nil {
+				newTx := NewTx(b.msgBlock.Transactions[i])
+				newTx.SetIndex(i)
+				b.transactions[i] = newTx
+			}
+			b.transactions[i].serializedTx = rawMsg[loc.TxStart : loc.TxStart+loc.TxLen]
+		}
+		b.txGenerated = true
+	}
+
 	return txLocs, err
 }
 
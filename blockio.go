@@ -0,0 +1,155 @@
+// Copyright (c) 2013 Conformal Systems LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/conformal/btcwire"
+)
+
+// maxTxPerBlock is a sanity limit on the number of transactions a block can
+// claim to contain.  The smallest possible serialized transaction is around
+// 60 bytes, so this mirrors the cap the reference implementation derives
+// from the maximum standard block size.  NewBlockFromReader checks the
+// decoded transaction count against it before allocating for that many
+// transactions, since the count comes straight off an untrusted reader
+// (a network peer or a blk*.dat entry) and is otherwise unbounded.
+const maxTxPerBlock = 1000000 / 60
+
+// NewBlockFromReader returns a new instance of a bitcoin block given a
+// reader to deserialize the block from.  See Block.
+//
+// Unlike NewBlockFromBytes, this never buffers the full serialized block in
+// memory.  The header and transaction count are decoded directly from r, and
+// each transaction is then deserialized one at a time, with only that single
+// transaction's bytes held in memory long enough to cache it on the
+// resulting Tx.  Transaction offsets are not recorded while reading: a later
+// call to TxLoc still serializes the whole block and walks it to compute
+// them.
+func NewBlockFromReader(r io.Reader) (*Block, error) {
+	var msgBlock btcwire.MsgBlock
+	if err := msgBlock.Header.Deserialize(r); err != nil {
+		return nil, err
+	}
+
+	txCount, err := btcwire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if txCount > maxTxPerBlock {
+		return nil, fmt.Errorf("block claims %d transactions which "+
+			"exceeds the maximum allowed %d", txCount, maxTxPerBlock)
+	}
+
+	b := Block{
+		msgBlock:    &msgBlock,
+		blockHeight: BlockHeightUnknown,
+	}
+
+	msgBlock.Transactions = make([]*btcwire.MsgTx, txCount)
+	b.transactions = make([]*Tx, txCount)
+	for i := uint64(0); i < txCount; i++ {
+		// Tee the bytes consumed while decoding this transaction into a
+		// small per-transaction buffer rather than the whole block, so the
+		// resulting Tx can be cached without re-serializing it later.
+		var txBuf bytes.Buffer
+		tr := io.TeeReader(r, &txBuf)
+
+		var msgTx btcwire.MsgTx
+		if err := msgTx.Deserialize(tr); err != nil {
+			return nil, err
+		}
+		msgBlock.Transactions[i] = &msgTx
+
+		newTx := NewTx(&msgTx)
+		newTx.SetIndex(int(i))
+		newTx.serializedTx = txBuf.Bytes()
+		b.transactions[i] = newTx
+	}
+	b.txGenerated = true
+
+	return &b, nil
+}
+
+// Serialize writes the block to w.  When the block's serialized bytes are
+// already cached, they are written directly; otherwise the underlying
+// btcwire.MsgBlock is serialized straight to w without allocating and
+// caching the full serialized block.
+func (b *Block) Serialize(w io.Writer) error {
+	if len(b.serializedBlock) != 0 {
+		_, err := w.Write(b.serializedBlock)
+		return err
+	}
+
+	return b.msgBlock.Serialize(w)
+}
+
+// SerializeTx writes the serialized bytes for the transaction at the
+// specified index in the Block to w.  The supplied index is 0 based.  When
+// the transaction's serialized bytes are already cached, they are written
+// directly; otherwise the underlying btcwire.MsgTx is serialized straight to
+// w without allocating the full serialized block.
+func (b *Block) SerializeTx(txNum int, w io.Writer) error {
+	tx, err := b.Tx(txNum)
+	if err != nil {
+		return err
+	}
+
+	if len(tx.serializedTx) != 0 {
+		_, err := w.Write(tx.serializedTx)
+		return err
+	}
+
+	return tx.msgTx.Serialize(w)
+}
+
+// BlockFileReader iterates the blocks contained in a Bitcoin Core style
+// blk*.dat file, where each serialized block is framed by a 4-byte network
+// magic value followed by a 4-byte little-endian length, yielding Block
+// values lazily instead of requiring the entire file to be loaded at once.
+type BlockFileReader struct {
+	r     io.Reader
+	magic btcwire.BitcoinNet
+}
+
+// NewBlockFileReader returns a new BlockFileReader that reads blocks framed
+// for the given network from r.
+func NewBlockFileReader(r io.Reader, magic btcwire.BitcoinNet) *BlockFileReader {
+	return &BlockFileReader{r: r, magic: magic}
+}
+
+// NextBlock reads and returns the next block in the file.  It returns io.EOF
+// once there are no more blocks left to read.
+func (r *BlockFileReader) NextBlock() (*Block, error) {
+	var frame [8]byte
+	if _, err := io.ReadFull(r.r, frame[:]); err != nil {
+		// An EOF while reading the frame header just means the file has
+		// been fully consumed.
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return nil, err
+	}
+
+	magic := btcwire.BitcoinNet(binary.LittleEndian.Uint32(frame[0:4]))
+	if magic == 0 {
+		// blk*.dat files are preallocated and padded with trailing zero
+		// bytes, so a zero magic marks the end of the usable blocks rather
+		// than a malformed frame.
+		return nil, io.EOF
+	}
+	if magic != r.magic {
+		return nil, fmt.Errorf("unexpected network magic %x in block "+
+			"file - want %x", magic, r.magic)
+	}
+
+	blockLen := binary.LittleEndian.Uint32(frame[4:8])
+	lr := io.LimitReader(r.r, int64(blockLen))
+	return NewBlockFromReader(lr)
+}
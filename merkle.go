@@ -0,0 +1,137 @@
+// Copyright (c) 2013 Conformal Systems LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"fmt"
+
+	"github.com/MassGrid/btcutil/chainhash"
+)
+
+// HashMerkleBranches takes two hashes, treated as the left and right tree
+// nodes, and returns the hash of their concatenation.  This is a helper
+// function used to aid in the generation of a merkle tree.
+func HashMerkleBranches(left, right *chainhash.Hash) *chainhash.Hash {
+	var hash [chainhash.HashSize * 2]byte
+	copy(hash[:chainhash.HashSize], left[:])
+	copy(hash[chainhash.HashSize:], right[:])
+	newHash := chainhash.DoubleHashH(hash[:])
+	return &newHash
+}
+
+// buildMerkleTree builds and caches the full merkle tree for the block's
+// transactions as a slice of levels, with the leaves (the transaction
+// hashes) at index 0 and the root by itself in the last level.  A level with
+// an odd number of entries duplicates its last hash to pair it with itself.
+//
+// It returns an error if any level contains two adjacent hashes at an even
+// position (i.e. a real pair, before any odd-length padding is considered),
+// which is the duplicate-transaction condition described in CVE-2012-2459:
+// appending a literal copy of a block's last transaction turns an odd
+// transaction count even and reproduces the same merkle root as the
+// original block.
+func (b *Block) buildMerkleTree() ([][]*chainhash.Hash, error) {
+	if b.merkleTree != nil {
+		return b.merkleTree, nil
+	}
+
+	txHashes, err := b.TxHashes()
+	if err != nil {
+		return nil, err
+	}
+	if len(txHashes) == 0 {
+		return nil, fmt.Errorf("block has no transactions")
+	}
+
+	level := make([]*chainhash.Hash, len(txHashes))
+	copy(level, txHashes)
+
+	levels := [][]*chainhash.Hash{level}
+	for len(level) > 1 {
+		for i := 0; i+1 < len(level); i += 2 {
+			if level[i].IsEqual(level[i+1]) {
+				return nil, fmt.Errorf("block contains duplicate adjacent " +
+					"transaction hashes")
+			}
+		}
+
+		nextLevel := make([]*chainhash.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			right := i + 1
+			if right == len(level) {
+				right = i
+			}
+			nextLevel = append(nextLevel, HashMerkleBranches(level[i], level[right]))
+		}
+
+		level = nextLevel
+		levels = append(levels, level)
+	}
+
+	b.merkleTree = levels
+	return levels, nil
+}
+
+// MerkleRoot returns the merkle root computed from the block's cached
+// transaction hashes, building and caching the full tree on first access so
+// subsequent calls to MerkleRoot and MerkleProof are O(log n).
+//
+// This returns *chainhash.Hash rather than *btcwire.ShaHash to stay
+// consistent with Block.Hash and Block.TxHashes, which already made that
+// switch.
+func (b *Block) MerkleRoot() (*chainhash.Hash, error) {
+	levels, err := b.buildMerkleTree()
+	if err != nil {
+		return nil, err
+	}
+
+	return levels[len(levels)-1][0], nil
+}
+
+// VerifyMerkleRoot reports whether the block's computed merkle root matches
+// the merkle root recorded in its header, returning an error describing the
+// mismatch, or any failure encountered while computing the root, otherwise.
+func (b *Block) VerifyMerkleRoot() error {
+	root, err := b.MerkleRoot()
+	if err != nil {
+		return err
+	}
+
+	headerRoot := b.msgBlock.Header.MerkleRoot
+	if !root.IsEqual((*chainhash.Hash)(&headerRoot)) {
+		return fmt.Errorf("block merkle root is invalid - block header "+
+			"indicates %v, but calculated value is %v", headerRoot, root)
+	}
+	return nil
+}
+
+// MerkleProof returns the sibling hashes, ordered bottom-to-top, needed to
+// recompute the merkle root from the hash of the transaction at txNum.  The
+// supplied index is 0 based.  The result is suitable for SPV verification.
+func (b *Block) MerkleProof(txNum int) ([]*chainhash.Hash, error) {
+	levels, err := b.buildMerkleTree()
+	if err != nil {
+		return nil, err
+	}
+
+	numTx := len(levels[0])
+	if txNum < 0 || txNum > numTx-1 {
+		str := fmt.Sprintf("transaction index %d is out of range - max %d",
+			txNum, numTx-1)
+		return nil, OutOfRangeError(str)
+	}
+
+	proof := make([]*chainhash.Hash, 0, len(levels)-1)
+	index := txNum
+	for _, level := range levels[:len(levels)-1] {
+		sibling := index ^ 1
+		if sibling >= len(level) {
+			sibling = index
+		}
+		proof = append(proof, level[sibling])
+		index /= 2
+	}
+	return proof, nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2013 Conformal Systems LLC.
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcutil
+
+import (
+	"testing"
+
+	"github.com/MassGrid/btcutil/chainhash"
+	"github.com/conformal/btcwire"
+)
+
+// newTestHash returns a chainhash.Hash with its first byte set to b, making
+// test hashes easy to tell apart.
+func newTestHash(b byte) *chainhash.Hash {
+	var h chainhash.Hash
+	h[0] = b
+	return &h
+}
+
+// TestBuildMerkleTreeDuplicateTx ensures buildMerkleTree rejects a block
+// whose transaction hashes contain a duplicated adjacent pair.  This is the
+// CVE-2012-2459 condition: appending a literal copy of the last transaction
+// to a block with an odd transaction count produces a merkle root that is
+// bit-for-bit identical to the original block's.
+func TestBuildMerkleTreeDuplicateTx(t *testing.T) {
+	a, b, c := newTestHash(0xaa), newTestHash(0xbb), newTestHash(0xcc)
+
+	orig := &Block{
+		txHashes:          []*chainhash.Hash{a, b, c},
+		txHashesGenerated: true,
+	}
+	if _, err := orig.buildMerkleTree(); err != nil {
+		t.Fatalf("unexpected error for a legitimate odd-sized tree: %v", err)
+	}
+
+	mutated := &Block{
+		txHashes:          []*chainhash.Hash{a, b, c, c},
+		txHashesGenerated: true,
+	}
+	if _, err := mutated.buildMerkleTree(); err == nil {
+		t.Fatal("expected error for a block with a duplicated adjacent " +
+			"transaction hash, got nil")
+	}
+}
+
+// TestMerkleRootAndProof exercises MerkleRoot, VerifyMerkleRoot and
+// MerkleProof together against a small hand-computed four-leaf tree.
+func TestMerkleRootAndProof(t *testing.T) {
+	h1, h2, h3, h4 := newTestHash(1), newTestHash(2), newTestHash(3), newTestHash(4)
+
+	b := &Block{
+		msgBlock:          &btcwire.MsgBlock{},
+		txHashes:          []*chainhash.Hash{h1, h2, h3, h4},
+		txHashesGenerated: true,
+	}
+
+	left := HashMerkleBranches(h1, h2)
+	right := HashMerkleBranches(h3, h4)
+	wantRoot := HashMerkleBranches(left, right)
+
+	root, err := b.MerkleRoot()
+	if err != nil {
+		t.Fatalf("MerkleRoot: %v", err)
+	}
+	if !root.IsEqual(wantRoot) {
+		t.Fatalf("MerkleRoot = %v, want %v", root, wantRoot)
+	}
+
+	var sha btcwire.ShaHash
+	copy(sha[:], root[:])
+	b.msgBlock.Header.MerkleRoot = sha
+	if err := b.VerifyMerkleRoot(); err != nil {
+		t.Fatalf("VerifyMerkleRoot: %v", err)
+	}
+
+	proof, err := b.MerkleProof(2)
+	if err != nil {
+		t.Fatalf("MerkleProof: %v", err)
+	}
+	if len(proof) != 2 {
+		t.Fatalf("len(proof) = %d, want 2", len(proof))
+	}
+	if !proof[0].IsEqual(h4) {
+		t.Fatalf("proof[0] = %v, want %v", proof[0], h4)
+	}
+	if !proof[1].IsEqual(left) {
+		t.Fatalf("proof[1] = %v, want %v", proof[1], left)
+	}
+}